@@ -0,0 +1,111 @@
+package bloom
+
+import (
+	"errors"
+
+	"github.com/willf/bitset"
+)
+
+// blockBits is the size, in bits, of a single block. It matches a common
+// cache line size so that all k probes for a key touch exactly one line.
+const blockBits = 512
+
+// A BlockedBloomFilter is a Bloom filter whose bit array is partitioned
+// into blockBits-sized blocks. All k probes for a given key are confined
+// to a single block, chosen by the high bits of the key's hash, so a
+// lookup touches one cache line instead of k scattered lines. This comes
+// at the cost of a higher false positive rate (typically 10-20% worse
+// than a classic BloomFilter with the same m and k), since each block is
+// effectively its own smaller filter.
+type BlockedBloomFilter struct {
+	m      uint
+	k      uint
+	blocks uint
+	b      *bitset.BitSet
+}
+
+// NewBlocked creates a new BlockedBloomFilter with _m_ bits and _k_
+// hashing functions. m is rounded up to a whole number of blockBits-sized
+// blocks.
+func NewBlocked(m uint, k uint) (*BlockedBloomFilter, error) {
+	if m < 1 {
+		return nil, errors.New("NewBlocked m < 1")
+	} else if k < 1 {
+		return nil, errors.New("NewBlocked k < 1")
+	}
+	blocks := (m + blockBits - 1) / blockBits
+	return &BlockedBloomFilter{blocks * blockBits, k, blocks, bitset.New(blocks * blockBits)}, nil
+}
+
+// NewBlockedWithEstimates creates a new BlockedBloomFilter sized for about
+// n items at false positive rate fp, using the same m, k estimate as
+// NewWithEstimates.
+func NewBlockedWithEstimates(n uint, fp float64) (*BlockedBloomFilter, error) {
+	m, k := EstimateParameters(n, fp)
+	return NewBlocked(m, k)
+}
+
+// block returns the index of the block that data's probes are confined
+// to, chosen from the high bits of h1.
+func (f *BlockedBloomFilter) block(h1 uint64) uint {
+	return uint(h1>>32) % f.blocks
+}
+
+// location derives the ith probe's absolute bit offset within f.b, using
+// Kirsch-Mitzenmacher double hashing restricted to a single blockBits-wide
+// block.
+func (f *BlockedBloomFilter) location(block uint, h1, h2 uint64, i uint) uint {
+	offset := uint((h1 + uint64(i)*h2 + uint64(i*i)) % blockBits)
+	return block*blockBits + offset
+}
+
+// Cap returns the capacity, _m_, of a BlockedBloomFilter.
+func (f *BlockedBloomFilter) Cap() uint {
+	return f.m
+}
+
+// Add data to the BlockedBloomFilter. Returns the filter (allows chaining).
+func (f *BlockedBloomFilter) Add(data []byte) *BlockedBloomFilter {
+	h1, h2 := baseHashes(data)
+	block := f.block(h1)
+	for i := uint(0); i < f.k; i++ {
+		f.b.Set(f.location(block, h1, h2, i))
+	}
+	return f
+}
+
+// Test returns true if the data is in the BlockedBloomFilter, false
+// otherwise. If true, the result might be a false positive. If false, the
+// data is definitely not in the set.
+func (f *BlockedBloomFilter) Test(data []byte) bool {
+	h1, h2 := baseHashes(data)
+	block := f.block(h1)
+	for i := uint(0); i < f.k; i++ {
+		if !f.b.Test(f.location(block, h1, h2, i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAndAdd is the equivalent to calling Test(data) then Add(data).
+// Returns the result of Test.
+func (f *BlockedBloomFilter) TestAndAdd(data []byte) bool {
+	present := true
+	h1, h2 := baseHashes(data)
+	block := f.block(h1)
+	for i := uint(0); i < f.k; i++ {
+		l := f.location(block, h1, h2, i)
+		if !f.b.Test(l) {
+			present = false
+		}
+		f.b.Set(l)
+	}
+	return present
+}
+
+// ClearAll clears all the data in a BlockedBloomFilter, removing all keys.
+func (f *BlockedBloomFilter) ClearAll() *BlockedBloomFilter {
+	f.b.ClearAll()
+	return f
+}
@@ -0,0 +1,82 @@
+package bloom
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBlockedBasic(t *testing.T) {
+	f, err := NewBlocked(10000, 5)
+	if err != nil {
+		t.Fatalf("NewBlocked: %v", err)
+	}
+	n1 := []byte("Bess")
+	n2 := []byte("Jane")
+	f.Add(n1)
+	if !f.Test(n1) {
+		t.Errorf("%v should be in the set", n1)
+	}
+	if f.Test(n2) {
+		t.Errorf("%v should not be in the set", n2)
+	}
+	if f.TestAndAdd(n2) {
+		t.Errorf("%v should not be in the set before TestAndAdd", n2)
+	}
+	if !f.Test(n2) {
+		t.Errorf("%v should be in the set after TestAndAdd", n2)
+	}
+}
+
+func TestBlockedCapRoundsToBlock(t *testing.T) {
+	f, err := NewBlocked(1, 1)
+	if err != nil {
+		t.Fatalf("NewBlocked: %v", err)
+	}
+	if f.Cap()%blockBits != 0 {
+		t.Errorf("Cap() = %d, want a multiple of blockBits (%d)", f.Cap(), blockBits)
+	}
+}
+
+func TestBlockedClearAll(t *testing.T) {
+	f, _ := NewBlocked(1000, 4)
+	f.Add([]byte("x"))
+	f.ClearAll()
+	if f.Test([]byte("x")) {
+		t.Errorf("expected ClearAll to remove all keys")
+	}
+}
+
+func TestNewBlockedWithEstimates(t *testing.T) {
+	f, err := NewBlockedWithEstimates(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewBlockedWithEstimates: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		f.Add([]byte(strconv.Itoa(i)))
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.Test([]byte(strconv.Itoa(i))) {
+			t.Errorf("expected %d to be present after Add", i)
+		}
+	}
+}
+
+func BenchmarkBlockedAdd(b *testing.B) {
+	f, _ := NewBlocked(uint(b.N)*10, 5)
+	key := make([]byte, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		f.Add(key)
+	}
+}
+
+func BenchmarkBlockedTest(b *testing.B) {
+	f, _ := NewBlocked(uint(b.N)*10, 5)
+	key := make([]byte, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		f.Test(key)
+	}
+}
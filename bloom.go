@@ -1,8 +1,9 @@
 package bloom
 
 import (
-	"bytes"
+	"encoding/binary"
 	"errors"
+	"math"
 
 	"github.com/spaolacci/murmur3"
 	"github.com/willf/bitset"
@@ -24,16 +25,6 @@ func max(x, y uint) uint {
 	return y
 }
 
-func uintToBytes(value uint) []byte {
-	b := make([]byte, 4)
-	b[0] = (byte)(value)
-	b[1] = (byte)(value >> 8)
-	b[2] = (byte)(value >> 16)
-	b[3] = (byte)(value >> 24)
-
-	return b
-}
-
 // New creates a new Bloom filter with _m_ bits and _k_ hashing functions
 // We force _m_ and _k_ to be at least one to avoid panics.
 func New(m uint, k uint) (*BloomFilter, error) {
@@ -46,15 +37,85 @@ func New(m uint, k uint) (*BloomFilter, error) {
 	}
 }
 
-// location returns the ith hashed location using the four base hash values
-func (f *BloomFilter) location(data []byte, i uint) uint {
-	tempData := [][]byte{data, uintToBytes(i)}
-	result := bytes.Join(tempData, []byte(""))
-	hasher := murmur3.New64()
-	hasher.Write(result)
-	location := uint(hasher.Sum64()) % f.m
+// EstimateParameters estimates requirements for m and k, given the number of
+// items that are expected to be added, n, and the desired false positive
+// rate, p. It returns the optimal m and k values, using the standard
+// formulas m = ceil(-n*ln(p)/ln(2)^2) and k = ceil((m/n)*ln(2)). k is
+// always clamped to at least 1.
+func EstimateParameters(n uint, p float64) (m uint, k uint) {
+	m = uint(math.Ceil(-1 * float64(n) * math.Log(p) / math.Pow(math.Log(2), 2)))
+	k = uint(math.Ceil(math.Log(2) * float64(m) / float64(n)))
+	if k < 1 {
+		k = 1
+	}
+	return
+}
+
+// NewWithEstimates creates a new Bloom filter for about n items with a
+// false positive rate of p, choosing m and k via EstimateParameters. This
+// is the preferred constructor when the caller knows roughly how many
+// items will be added and the false positive rate they can tolerate,
+// rather than working out m and k by hand.
+func NewWithEstimates(n uint, fp float64) (*BloomFilter, error) {
+	m, k := EstimateParameters(n, fp)
+	return New(m, k)
+}
 
-	return location
+// EstimateFalsePositiveRate runs a Monte Carlo simulation to estimate the
+// false positive rate of the current filter's m and k when loaded with n
+// items, by filling a fresh filter with n distinct keys and testing 100000
+// further keys that were never added.
+// It is useful for sanity-checking the parameters chosen by
+// EstimateParameters or NewWithEstimates.
+func (f *BloomFilter) EstimateFalsePositiveRate(n uint) float64 {
+	rounds := uint32(100000)
+	probe, _ := New(f.m, f.k)
+	n1 := make([]byte, 4)
+	for i := uint32(0); i < uint32(n); i++ {
+		binary.BigEndian.PutUint32(n1, i)
+		probe.Add(n1)
+	}
+	fp := 0
+	for i := uint32(0); i < rounds; i++ {
+		binary.BigEndian.PutUint32(n1, i+uint32(n)+1)
+		if probe.Test(n1) {
+			fp++
+		}
+	}
+	return float64(fp) / float64(rounds)
+}
+
+// baseHashes returns the 128-bit murmur3 hash of data, split into two
+// uint64 halves h1 and h2.
+func baseHashes(data []byte) (h1 uint64, h2 uint64) {
+	return murmur3.Sum128(data)
+}
+
+// location derives the ith probe location from the two base hashes using
+// Kirsch-Mitzenmacher double hashing: (h1 + i*h2 + i*i) % m. The added
+// i*i term avoids degenerate cycles when h2 and m share factors, at the
+// cost of computing only one murmur3 hash per call instead of k. When m
+// is a power of two (as NewFromBuffer guarantees), the reduction is done
+// with a mask instead of a division.
+func (f *BloomFilter) location(h1, h2 uint64, i uint) uint {
+	probe := h1 + uint64(i)*h2 + uint64(i*i)
+	if f.m&(f.m-1) == 0 {
+		return uint(probe & (uint64(f.m) - 1))
+	}
+	return uint(probe % uint64(f.m))
+}
+
+// Locations returns the k probe locations for data, derived from a single
+// 128-bit murmur3 hash via double hashing. This lets callers precompute
+// probes once and reuse them with TestLocations, e.g. to batch lookups
+// without re-hashing.
+func (f *BloomFilter) Locations(data []byte) []uint64 {
+	h1, h2 := baseHashes(data)
+	locs := make([]uint64, f.k)
+	for i := uint(0); i < f.k; i++ {
+		locs[i] = uint64(f.location(h1, h2, i))
+	}
+	return locs
 }
 
 // Cap returns the capacity, _m_, of a Bloom filter
@@ -69,8 +130,9 @@ func (f *BloomFilter) HashFunctionNum() uint {
 
 // Add data to the Bloom Filter. Returns the filter (allows chaining)
 func (f *BloomFilter) Add(data []byte) *BloomFilter {
+	h1, h2 := baseHashes(data)
 	for i := uint(0); i < f.k; i++ {
-		f.b.Set(f.location(data, i))
+		f.b.Set(f.location(h1, h2, i))
 	}
 	return f
 }
@@ -84,8 +146,9 @@ func (f *BloomFilter) AddString(data string) *BloomFilter {
 // If true, the result might be a false positive. If false, the data
 // is definitely not in the set.
 func (f *BloomFilter) Test(data []byte) bool {
+	h1, h2 := baseHashes(data)
 	for i := uint(0); i < f.k; i++ {
-		if !f.b.Test(f.location(data, i)) {
+		if !f.b.Test(f.location(h1, h2, i)) {
 			return false
 		}
 	}
@@ -114,8 +177,9 @@ func (f *BloomFilter) TestLocations(locs []uint64) bool {
 // Returns the result of Test.
 func (f *BloomFilter) TestAndAdd(data []byte) bool {
 	present := true
+	h1, h2 := baseHashes(data)
 	for i := uint(0); i < f.k; i++ {
-		l := f.location(data, i)
+		l := f.location(h1, h2, i)
 		if !f.b.Test(l) {
 			present = false
 		}
@@ -135,3 +199,44 @@ func (f *BloomFilter) ClearAll() *BloomFilter {
 	f.b.ClearAll()
 	return f
 }
+
+// Copy returns a copy of f.
+func (f *BloomFilter) Copy() *BloomFilter {
+	return &BloomFilter{f.m, f.k, f.b.Clone()}
+}
+
+// Merge sets f to the union of f and other, i.e. a bitwise OR of their
+// underlying bitsets. A filter trained on the union of two disjoint sets
+// is indistinguishable from a filter built by merging filters trained on
+// each set separately, which makes this useful for sharded or parallel
+// indexing: build one filter per shard, then merge them into one. Returns
+// an error if m or k differ between the two filters.
+func (f *BloomFilter) Merge(other *BloomFilter) error {
+	if f.m != other.m {
+		return errors.New("Merge m does not match")
+	} else if f.k != other.k {
+		return errors.New("Merge k does not match")
+	}
+	f.b.InPlaceUnion(other.b)
+	return nil
+}
+
+// Intersect sets f to the intersection of f and other, i.e. a bitwise AND
+// of their underlying bitsets. The result tests positive only for keys
+// that both filters would report as present. Returns an error if m or k
+// differ between the two filters.
+func (f *BloomFilter) Intersect(other *BloomFilter) error {
+	if f.m != other.m {
+		return errors.New("Intersect m does not match")
+	} else if f.k != other.k {
+		return errors.New("Intersect k does not match")
+	}
+	f.b.InPlaceIntersection(other.b)
+	return nil
+}
+
+// Equal returns true if f and other have the same m, k, and bitset
+// contents.
+func (f *BloomFilter) Equal(other *BloomFilter) bool {
+	return f.m == other.m && f.k == other.k && f.b.Equal(other.b)
+}
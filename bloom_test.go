@@ -0,0 +1,125 @@
+package bloom
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBasic(t *testing.T) {
+	f, err := New(1000, 4)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	n1 := []byte("Bess")
+	n2 := []byte("Jane")
+	n3 := []byte("Emma")
+	f.Add(n1)
+	n3a := f.TestAndAdd(n3)
+	n1b := f.Test(n1)
+	n2b := f.Test(n2)
+	f.Test(n3)
+	if !n1b {
+		t.Errorf("%v should be in the set", n1)
+	}
+	if n2b {
+		t.Errorf("%v should not be in the set", n2)
+	}
+	if n3a {
+		t.Errorf("%v should not be in the set before TestAndAdd", n3)
+	}
+	if !f.Test(n3) {
+		t.Errorf("%v should be in the set after TestAndAdd", n3)
+	}
+}
+
+func TestLocationsAgreeWithProbes(t *testing.T) {
+	f, err := New(1000, 5)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	data := []byte("consistent probes")
+
+	locs := f.Locations(data)
+	if uint(len(locs)) != f.k {
+		t.Fatalf("Locations returned %d entries, want %d", len(locs), f.k)
+	}
+
+	f.Add(data)
+	if !f.TestLocations(locs) {
+		t.Errorf("TestLocations(locs) should be true once data has been Added via the same locations")
+	}
+
+	// Calling Locations twice for the same data must yield identical
+	// probes, since double hashing is meant to be deterministic.
+	locs2 := f.Locations(data)
+	for i := range locs {
+		if locs[i] != locs2[i] {
+			t.Errorf("Locations(%q) not deterministic: probe %d was %d, now %d", data, i, locs[i], locs2[i])
+		}
+	}
+}
+
+func TestEstimateParameters(t *testing.T) {
+	m, k := EstimateParameters(1000, 0.01)
+	if m == 0 || k == 0 {
+		t.Errorf("EstimateParameters(1000, 0.01) = (%d, %d), want both > 0", m, k)
+	}
+}
+
+func TestEstimateFalsePositiveRate(t *testing.T) {
+	n := uint(1000)
+	p := 0.01
+	f, err := NewWithEstimates(n, p)
+	if err != nil {
+		t.Fatalf("NewWithEstimates: %v", err)
+	}
+	for i := uint(0); i < n; i++ {
+		f.AddString(strconv.Itoa(int(i)))
+	}
+
+	got := f.EstimateFalsePositiveRate(n)
+	if got < 0 || got > 1 {
+		t.Fatalf("EstimateFalsePositiveRate = %v, want a probability in [0, 1]", got)
+	}
+	// The simulated rate should roughly track the target p; allow a
+	// generous margin since it's a Monte Carlo estimate over one
+	// particular filter instance rather than an average over many.
+	if got > p*3 {
+		t.Errorf("EstimateFalsePositiveRate = %v, want roughly on the order of the target false positive rate %v", got, p)
+	}
+}
+
+func TestNewWithEstimates(t *testing.T) {
+	f, err := NewWithEstimates(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewWithEstimates: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		f.AddString(strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.TestString(strconv.Itoa(i)) {
+			t.Errorf("expected %d to be present after Add", i)
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	f, _ := New(uint(b.N)*10, 5)
+	key := make([]byte, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		f.Add(key)
+	}
+}
+
+func BenchmarkTest(b *testing.B) {
+	f, _ := New(uint(b.N)*10, 5)
+	key := make([]byte, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key[0] = byte(i)
+		f.Test(key)
+	}
+}
@@ -0,0 +1,145 @@
+package bloom
+
+import (
+	"errors"
+)
+
+// counterWidth is the number of bits per counter in a CountingBloomFilter.
+// 4 bits gives a max count of 15 per slot, which is enough headroom for
+// most dedup/admission workloads while keeping memory at half a byte per
+// counter.
+const counterWidth = 4
+const counterMax = 1<<counterWidth - 1
+const countersPerByte = 8 / counterWidth
+
+// A CountingBloomFilter is a Bloom filter backed by an array of small
+// saturating counters instead of single bits, which lets individual keys
+// be removed without risking false negatives for keys that remain.
+// Counters saturate at their maximum value rather than overflowing, so a
+// saturated counter is simply never decremented by Remove.
+type CountingBloomFilter struct {
+	m uint
+	k uint
+	c []byte
+}
+
+// NewCounting creates a new CountingBloomFilter with _m_ counters and _k_
+// hashing functions.
+func NewCounting(m uint, k uint) (*CountingBloomFilter, error) {
+	if m < 1 {
+		return nil, errors.New("NewCounting m < 1")
+	} else if k < 1 {
+		return nil, errors.New("NewCounting k < 1")
+	}
+	return &CountingBloomFilter{m, k, make([]byte, (m+countersPerByte-1)/countersPerByte)}, nil
+}
+
+// NewCountingWithEstimates creates a new CountingBloomFilter sized for
+// about n items at false positive rate fp, using the same m, k estimate
+// as NewWithEstimates.
+func NewCountingWithEstimates(n uint, fp float64) (*CountingBloomFilter, error) {
+	m, k := EstimateParameters(n, fp)
+	return NewCounting(m, k)
+}
+
+// counter reads the counter at index i.
+func (f *CountingBloomFilter) counter(i uint) uint32 {
+	b := f.c[i/countersPerByte]
+	shift := (i % countersPerByte) * counterWidth
+	return uint32((b >> shift) & counterMax)
+}
+
+// setCounter writes v into the counter at index i. v is assumed to
+// already be clamped to [0, counterMax].
+func (f *CountingBloomFilter) setCounter(i uint, v uint32) {
+	shift := (i % countersPerByte) * counterWidth
+	mask := byte(counterMax) << shift
+	idx := i / countersPerByte
+	f.c[idx] = (f.c[idx] &^ mask) | (byte(v) << shift)
+}
+
+// incr increments the counter at index i, saturating at counterMax.
+func (f *CountingBloomFilter) incr(i uint) {
+	if v := f.counter(i); v < counterMax {
+		f.setCounter(i, v+1)
+	}
+}
+
+// decr decrements the counter at index i, unless it is saturated, in
+// which case it is left alone since we can no longer tell its true count.
+func (f *CountingBloomFilter) decr(i uint) {
+	if v := f.counter(i); v > 0 && v < counterMax {
+		f.setCounter(i, v-1)
+	}
+}
+
+// locations returns the k probe indices for data.
+func (f *CountingBloomFilter) locations(data []byte) []uint {
+	h1, h2 := baseHashes(data)
+	locs := make([]uint, f.k)
+	for i := uint(0); i < f.k; i++ {
+		locs[i] = uint((h1 + uint64(i)*h2 + uint64(i*i)) % uint64(f.m))
+	}
+	return locs
+}
+
+// Add data to the CountingBloomFilter. Returns the filter (allows
+// chaining).
+func (f *CountingBloomFilter) Add(data []byte) *CountingBloomFilter {
+	for _, l := range f.locations(data) {
+		f.incr(l)
+	}
+	return f
+}
+
+// Test returns true if the data is in the CountingBloomFilter, false
+// otherwise. If true, the result might be a false positive. If false, the
+// data is definitely not in the set.
+func (f *CountingBloomFilter) Test(data []byte) bool {
+	for _, l := range f.locations(data) {
+		if f.counter(l) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the minimum counter value across data's k probes, an
+// approximation of how many times data (or a colliding key) has been
+// added without being removed.
+func (f *CountingBloomFilter) Count(data []byte) uint32 {
+	min := uint32(counterMax)
+	for _, l := range f.locations(data) {
+		if v := f.counter(l); v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// Remove removes data from the CountingBloomFilter, decrementing each of
+// its k counters. Counters that have saturated are left untouched, since
+// a saturated counter may be shared with other keys whose true count we
+// no longer know. Returns the filter (allows chaining).
+func (f *CountingBloomFilter) Remove(data []byte) *CountingBloomFilter {
+	for _, l := range f.locations(data) {
+		f.decr(l)
+	}
+	return f
+}
+
+// TestAndRemove is the equivalent of calling Test(data) then Remove(data).
+// Returns the result of Test.
+func (f *CountingBloomFilter) TestAndRemove(data []byte) bool {
+	present := f.Test(data)
+	f.Remove(data)
+	return present
+}
+
+// ClearAll clears all the data in a CountingBloomFilter, removing all keys.
+func (f *CountingBloomFilter) ClearAll() *CountingBloomFilter {
+	for i := range f.c {
+		f.c[i] = 0
+	}
+	return f
+}
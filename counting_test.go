@@ -0,0 +1,105 @@
+package bloom
+
+import "testing"
+
+func TestCountingBasic(t *testing.T) {
+	f, err := NewCounting(1000, 4)
+	if err != nil {
+		t.Fatalf("NewCounting: %v", err)
+	}
+	n1 := []byte("Bess")
+	n2 := []byte("Jane")
+
+	f.Add(n1)
+	if !f.Test(n1) {
+		t.Errorf("%v should be in the set", n1)
+	}
+	if f.Test(n2) {
+		t.Errorf("%v should not be in the set", n2)
+	}
+}
+
+func TestCountingRemove(t *testing.T) {
+	f, _ := NewCounting(1000, 4)
+	n1 := []byte("Bess")
+
+	f.Add(n1)
+	f.Remove(n1)
+	if f.Test(n1) {
+		t.Errorf("%v should not be in the set after Remove", n1)
+	}
+}
+
+func TestCountingRemoveDoesNotAffectOtherKeys(t *testing.T) {
+	f, _ := NewCounting(1000, 4)
+	n1 := []byte("Bess")
+	n2 := []byte("Jane")
+
+	f.Add(n1)
+	f.Add(n2)
+	f.Remove(n1)
+	if !f.Test(n2) {
+		t.Errorf("removing %v should not remove %v", n1, n2)
+	}
+}
+
+func TestCountingTestAndRemove(t *testing.T) {
+	f, _ := NewCounting(1000, 4)
+	n1 := []byte("Bess")
+
+	if f.TestAndRemove(n1) {
+		t.Errorf("TestAndRemove should report absent before Add")
+	}
+	f.Add(n1)
+	if !f.TestAndRemove(n1) {
+		t.Errorf("TestAndRemove should report present right after Add")
+	}
+	if f.Test(n1) {
+		t.Errorf("%v should be gone after TestAndRemove", n1)
+	}
+}
+
+func TestCountingCount(t *testing.T) {
+	f, _ := NewCounting(1000, 4)
+	n1 := []byte("Bess")
+
+	if c := f.Count(n1); c != 0 {
+		t.Errorf("Count before Add = %d, want 0", c)
+	}
+	f.Add(n1)
+	f.Add(n1)
+	if c := f.Count(n1); c != 2 {
+		t.Errorf("Count after two Adds = %d, want 2", c)
+	}
+}
+
+func TestCountingSaturatesAndNeverUnderflows(t *testing.T) {
+	f, _ := NewCounting(1000, 4)
+	n1 := []byte("Bess")
+
+	for i := 0; i < counterMax+10; i++ {
+		f.Add(n1)
+	}
+	if c := f.Count(n1); c != counterMax {
+		t.Errorf("Count after saturation = %d, want %d", c, counterMax)
+	}
+
+	// A saturated counter must never be decremented below what it would
+	// take to make Test return a false negative for a key that is still
+	// logically present.
+	f.Remove(n1)
+	if !f.Test(n1) {
+		t.Errorf("removing once from a saturated counter must not cause a false negative")
+	}
+}
+
+func TestNewCountingWithEstimates(t *testing.T) {
+	f, err := NewCountingWithEstimates(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewCountingWithEstimates: %v", err)
+	}
+	f.Add([]byte("x"))
+	if !f.Test([]byte("x")) {
+		t.Errorf("expected x to be present after Add")
+	}
+}
@@ -0,0 +1,246 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// maxSubFilterBits caps how many bits a single sub-filter's capacityFor
+// estimate may request. Left unchecked, the i-th sub-filter's target size
+// n0*s^i grows and its tightened false positive rate p*(1-r)*r^i shrinks
+// every rotation, so m grows faster than exponentially in i; a
+// long-running filter would eventually ask bitset.New() for an
+// allocation large enough to OOM the process. Once the estimate would
+// exceed this many bits, n is clamped down to fit instead.
+const maxSubFilterBits = 1 << 30 // 1 Gib of bits, 128 MiB per sub-filter
+
+// maxSubFilters caps the number of sub-filters a ScalableBloomFilter will
+// ever allocate. Once reached, addFilter refuses to rotate and Add keeps
+// inserting into the last (now over-full) sub-filter instead, trading
+// false-positive accuracy for a hard ceiling on total memory use.
+const maxSubFilters = 32
+
+// A ScalableBloomFilter maintains a slice of BloomFilters, adding new,
+// larger, tighter ones as earlier ones fill up, so callers don't need to
+// know the total number of items n up front. The i-th sub-filter is sized
+// for n0*s^i items at false positive rate p*(1-r)*r^i, which keeps the
+// geometric sum of the sub-filters' FP rates bounded by the overall
+// target p, up to the limits documented on maxSubFilterBits and
+// maxSubFilters: beyond those, capacity and false positive rate are no
+// longer strictly guaranteed, in exchange for never exhausting memory.
+type ScalableBloomFilter struct {
+	n0      uint
+	p       float64
+	s       float64
+	r       float64
+	filled  []uint // number of items added to each filter, in order
+	filters []*BloomFilter
+}
+
+// NewScalable creates a new ScalableBloomFilter with initial capacity n0,
+// target overall false positive rate p, growth factor s (e.g. 2 or 4),
+// and tightening ratio r (e.g. 0.9).
+func NewScalable(n0 uint, p float64, s float64, r float64) (*ScalableBloomFilter, error) {
+	if n0 < 1 {
+		return nil, errors.New("NewScalable n0 < 1")
+	} else if p <= 0 || p >= 1 {
+		return nil, errors.New("NewScalable p must be in (0, 1)")
+	} else if s <= 1 {
+		return nil, errors.New("NewScalable s must be > 1")
+	} else if r <= 0 || r >= 1 {
+		return nil, errors.New("NewScalable r must be in (0, 1)")
+	}
+	sb := &ScalableBloomFilter{n0: n0, p: p, s: s, r: r}
+	if err := sb.addFilter(); err != nil {
+		return nil, err
+	}
+	return sb, nil
+}
+
+// capacityFor returns the capacity and false positive rate of the i-th
+// sub-filter, clamping n so the resulting m never exceeds
+// maxSubFilterBits.
+func (sb *ScalableBloomFilter) capacityFor(i int) (n uint, p float64) {
+	n = uint(float64(sb.n0) * pow(sb.s, i))
+	if n < 1 {
+		n = 1
+	}
+	p = sb.p * (1 - sb.r) * pow(sb.r, i)
+
+	if m, _ := EstimateParameters(n, p); m > maxSubFilterBits {
+		if maxN := uint(float64(maxSubFilterBits) * math.Pow(math.Log(2), 2) / -math.Log(p)); maxN >= 1 {
+			n = maxN
+		} else {
+			n = 1
+		}
+	}
+	return
+}
+
+// pow computes base raised to a non-negative integer exponent.
+func pow(base float64, exp int) float64 {
+	result := 1.0
+	for i := 0; i < exp; i++ {
+		result *= base
+	}
+	return result
+}
+
+// addFilter appends a new, appropriately sized sub-filter to sb. It
+// refuses once maxSubFilters have already been allocated, or if the
+// sized sub-filter's parameters are themselves invalid (e.g. p has
+// tightened to 0), so callers must not assume rotation always succeeds.
+func (sb *ScalableBloomFilter) addFilter() error {
+	if len(sb.filters) >= maxSubFilters {
+		return errors.New("ScalableBloomFilter: max sub-filter count reached")
+	}
+	n, p := sb.capacityFor(len(sb.filters))
+	if p <= 0 {
+		return errors.New("ScalableBloomFilter: sub-filter false positive rate underflowed to 0")
+	}
+	f, err := NewWithEstimates(n, p)
+	if err != nil {
+		return err
+	}
+	sb.filters = append(sb.filters, f)
+	sb.filled = append(sb.filled, 0)
+	return nil
+}
+
+// current returns the last (currently active) sub-filter.
+func (sb *ScalableBloomFilter) current() *BloomFilter {
+	return sb.filters[len(sb.filters)-1]
+}
+
+// Add inserts data into the current sub-filter, rotating to a new, larger
+// sub-filter first if the current one's estimated fill has reached its
+// capacity. If rotation fails (maxSubFilters reached, or the next
+// sub-filter's parameters are no longer valid), Add falls back to
+// inserting into the current, over-full sub-filter rather than rotating,
+// so the false positive rate degrades gracefully instead of panicking.
+// Returns the filter (allows chaining).
+func (sb *ScalableBloomFilter) Add(data []byte) *ScalableBloomFilter {
+	last := len(sb.filters) - 1
+	capN, _ := sb.capacityFor(last)
+	if sb.filled[last] >= capN {
+		if err := sb.addFilter(); err == nil {
+			last++
+		}
+	}
+	sb.current().Add(data)
+	sb.filled[last]++
+	return sb
+}
+
+// Test returns true if data is present in any sub-filter. If true, the
+// result might be a false positive. If false, the data is definitely not
+// in the set.
+func (sb *ScalableBloomFilter) Test(data []byte) bool {
+	for _, f := range sb.filters {
+		if f.Test(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAndAdd is the equivalent of calling Test(data) then Add(data).
+// Returns the result of Test.
+func (sb *ScalableBloomFilter) TestAndAdd(data []byte) bool {
+	present := sb.Test(data)
+	sb.Add(data)
+	return present
+}
+
+// FillRatio returns the fraction of the current sub-filter's estimated
+// capacity that has been used, as a diagnostic for how close the filter
+// is to growing again.
+func (sb *ScalableBloomFilter) FillRatio() float64 {
+	last := len(sb.filters) - 1
+	capN, _ := sb.capacityFor(last)
+	return float64(sb.filled[last]) / float64(capN)
+}
+
+// WriteTo writes a binary encoding of sb to w: n0, p, s, r, the number of
+// sub-filters, their fill counts, and then each sub-filter in the format
+// written by BloomFilter.WriteTo. It returns the number of bytes written.
+func (sb *ScalableBloomFilter) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	header := []interface{}{uint64(sb.n0), sb.p, sb.s, sb.r, uint64(len(sb.filters))}
+	for _, v := range header {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return written, err
+		}
+		written += int64(binary.Size(v))
+	}
+	for i, f := range sb.filters {
+		filled := uint64(sb.filled[i])
+		if err := binary.Write(w, binary.BigEndian, filled); err != nil {
+			return written, err
+		}
+		written += int64(binary.Size(filled))
+		n, err := f.WriteTo(w)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ReadFrom reads a binary encoding produced by WriteTo from r, replacing
+// sb's contents. It returns the number of bytes read.
+func (sb *ScalableBloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	var read int64
+	var n0, count uint64
+	var p, s, rr float64
+	for _, v := range []interface{}{&n0, &p, &s, &rr, &count} {
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			return read, err
+		}
+		read += int64(binary.Size(v))
+	}
+
+	filters := make([]*BloomFilter, count)
+	filled := make([]uint, count)
+	for i := uint64(0); i < count; i++ {
+		var f uint64
+		if err := binary.Read(r, binary.BigEndian, &f); err != nil {
+			return read, err
+		}
+		read += int64(binary.Size(f))
+		filled[i] = uint(f)
+
+		bf := &BloomFilter{}
+		n, err := bf.ReadFrom(r)
+		read += n
+		if err != nil {
+			return read, err
+		}
+		filters[i] = bf
+	}
+
+	sb.n0 = uint(n0)
+	sb.p = p
+	sb.s = s
+	sb.r = rr
+	sb.filters = filters
+	sb.filled = filled
+	return read, nil
+}
+
+// GobEncode implements gob.GobEncoder, delegating to WriteTo.
+func (sb *ScalableBloomFilter) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := sb.WriteTo(&buf)
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder, delegating to ReadFrom.
+func (sb *ScalableBloomFilter) GobDecode(data []byte) error {
+	_, err := sb.ReadFrom(bytes.NewReader(data))
+	return err
+}
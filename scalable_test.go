@@ -0,0 +1,115 @@
+package bloom
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestScalableBasic(t *testing.T) {
+	sb, err := NewScalable(100, 0.01, 2, 0.9)
+	if err != nil {
+		t.Fatalf("NewScalable: %v", err)
+	}
+	sb.Add([]byte("x"))
+	if !sb.Test([]byte("x")) {
+		t.Errorf("x should be present after Add")
+	}
+	if sb.Test([]byte("y")) {
+		t.Errorf("y should not be present")
+	}
+}
+
+func TestScalableRotatesAcrossManyItems(t *testing.T) {
+	sb, _ := NewScalable(10, 0.01, 2, 0.9)
+	for i := 0; i < 5000; i++ {
+		sb.Add([]byte(strconv.Itoa(i)))
+	}
+	if len(sb.filters) < 2 {
+		t.Errorf("expected ScalableBloomFilter to have rotated to more than one sub-filter, got %d", len(sb.filters))
+	}
+	for i := 0; i < 5000; i++ {
+		if !sb.Test([]byte(strconv.Itoa(i))) {
+			t.Errorf("expected %d to be present after Add", i)
+		}
+	}
+}
+
+func TestScalableTestAndAdd(t *testing.T) {
+	sb, _ := NewScalable(100, 0.01, 2, 0.9)
+	if sb.TestAndAdd([]byte("x")) {
+		t.Errorf("TestAndAdd should report absent before Add")
+	}
+	if !sb.TestAndAdd([]byte("x")) {
+		t.Errorf("TestAndAdd should report present right after the first Add")
+	}
+}
+
+func TestScalableFillRatio(t *testing.T) {
+	sb, _ := NewScalable(10, 0.01, 2, 0.9)
+	if r := sb.FillRatio(); r != 0 {
+		t.Errorf("FillRatio of a fresh filter = %v, want 0", r)
+	}
+	sb.Add([]byte("x"))
+	if r := sb.FillRatio(); r <= 0 {
+		t.Errorf("FillRatio after one Add = %v, want > 0", r)
+	}
+}
+
+// TestScalableCappedGrowthDoesNotPanic drives addFilter past maxSubFilters
+// and confirms Add degrades gracefully (keeps inserting into the last
+// sub-filter) instead of indexing past sb.filters/sb.filled.
+func TestScalableCappedGrowthDoesNotPanic(t *testing.T) {
+	sb, _ := NewScalable(1, 0.5, 4, 0.9)
+	for len(sb.filters) < maxSubFilters {
+		if err := sb.addFilter(); err != nil {
+			t.Fatalf("addFilter unexpectedly failed before reaching maxSubFilters: %v", err)
+		}
+	}
+	// sb is now at the cap; any further rotation attempt must fail...
+	if err := sb.addFilter(); err == nil {
+		t.Fatalf("addFilter should fail once maxSubFilters is reached")
+	}
+	// ...and Add must still work without panicking or growing sb.filters.
+	// Fill the last sub-filter to its capacity so Add actually tries to
+	// rotate and has to fall back.
+	last := len(sb.filters) - 1
+	sb.filled[last], _ = sb.capacityFor(last)
+	before := len(sb.filters)
+	sb.Add([]byte("past the cap"))
+	if len(sb.filters) != before {
+		t.Errorf("Add should not have rotated past maxSubFilters")
+	}
+}
+
+func TestScalableCapacityForBoundsBits(t *testing.T) {
+	sb, _ := NewScalable(1000, 0.01, 2, 0.9)
+	for i := 0; i < maxSubFilters; i++ {
+		n, p := sb.capacityFor(i)
+		if m, _ := EstimateParameters(n, p); m > maxSubFilterBits {
+			t.Errorf("capacityFor(%d) implies m = %d bits, exceeding maxSubFilterBits (%d)", i, m, maxSubFilterBits)
+		}
+	}
+}
+
+func TestScalableWriteToReadFrom(t *testing.T) {
+	sb, _ := NewScalable(10, 0.01, 2, 0.9)
+	for i := 0; i < 500; i++ {
+		sb.Add([]byte(strconv.Itoa(i)))
+	}
+
+	var buf bytes.Buffer
+	if _, err := sb.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	sb2 := &ScalableBloomFilter{}
+	if _, err := sb2.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	for i := 0; i < 500; i++ {
+		if !sb2.Test([]byte(strconv.Itoa(i))) {
+			t.Errorf("expected %d to be present after round-trip", i)
+		}
+	}
+}
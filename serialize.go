@@ -0,0 +1,161 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"unsafe"
+
+	"github.com/willf/bitset"
+)
+
+// binaryMagic and binaryVersion identify the on-disk format written by
+// WriteTo, so ReadFrom can reject data written by an incompatible version
+// instead of silently misinterpreting it.
+const (
+	binaryMagic   = uint16(0xB10F)
+	binaryVersion = uint8(1)
+)
+
+// WriteTo writes a compact, self-describing binary encoding of f to w:
+// magic bytes, a version byte, m and k as fixed-width uint64s, and then
+// the underlying bitset words. It returns the number of bytes written.
+func (f *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	header := []interface{}{binaryMagic, binaryVersion, uint64(f.m), uint64(f.k)}
+	var written int64
+	for _, v := range header {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return written, err
+		}
+		written += int64(binary.Size(v))
+	}
+	n, err := f.b.WriteTo(w)
+	return written + n, err
+}
+
+// ReadFrom reads a binary encoding produced by WriteTo from r, replacing
+// f's contents. It returns the number of bytes read.
+func (f *BloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	var magic uint16
+	var version uint8
+	var m, k uint64
+	var read int64
+
+	for _, v := range []interface{}{&magic, &version, &m, &k} {
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			return read, err
+		}
+		read += int64(binary.Size(v))
+	}
+	if magic != binaryMagic {
+		return read, errors.New("ReadFrom: not a BloomFilter (bad magic)")
+	}
+	if version != binaryVersion {
+		return read, errors.New("ReadFrom: unsupported BloomFilter version")
+	}
+
+	b := &bitset.BitSet{}
+	n, err := b.ReadFrom(r)
+	if err != nil {
+		return read, err
+	}
+
+	f.m = uint(m)
+	f.k = uint(k)
+	f.b = b
+	return read + n, nil
+}
+
+// GobEncode implements gob.GobEncoder, delegating to WriteTo.
+func (f *BloomFilter) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := f.WriteTo(&buf)
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder, delegating to ReadFrom.
+func (f *BloomFilter) GobDecode(data []byte) error {
+	_, err := f.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// bloomJSON mirrors BloomFilter's exported shape for JSON interop, since
+// m, k, and b are unexported and the bitset itself already knows how to
+// marshal its words.
+type bloomJSON struct {
+	M uint           `json:"m"`
+	K uint           `json:"k"`
+	B *bitset.BitSet `json:"b"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f *BloomFilter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bloomJSON{f.m, f.k, f.b})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *BloomFilter) UnmarshalJSON(data []byte) error {
+	var j bloomJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	f.m = j.M
+	f.k = j.K
+	f.b = j.B
+	return nil
+}
+
+// NewFromBuffer wraps a caller-supplied backing slice as a BloomFilter
+// with k hash functions. buf is reinterpreted in place as the filter's
+// bitset words, with no copy, which is the form syscall.Mmap hands back
+// — the intended use is backing a filter directly by an mmap'd, read-only
+// index. m is rounded up to the nearest power of two bit count so
+// location() can reduce probes with a mask instead of a division; when
+// buf's own bit length is already a power of two (as it will be for an
+// index sized that way up front), this rounding is free and the filter
+// stays zero-copy. Otherwise the bits are copied once into a freshly
+// grown bitset, trading the zero-copy property for mask-based probing.
+func NewFromBuffer(buf []byte, k uint) (*BloomFilter, error) {
+	if k < 1 {
+		return nil, errors.New("NewFromBuffer k < 1")
+	}
+	words, err := bytesToWords(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bitset.From(words)
+	m := nextPowerOfTwo(b.Len())
+	if m != b.Len() {
+		grown := bitset.New(m)
+		grown.InPlaceUnion(b)
+		b = grown
+	}
+	return &BloomFilter{m, k, b}, nil
+}
+
+// bytesToWords reinterprets buf as a []uint64 of its underlying words,
+// without copying. buf's length must be a non-zero multiple of 8 bytes.
+func bytesToWords(buf []byte) ([]uint64, error) {
+	if len(buf) == 0 {
+		return nil, errors.New("NewFromBuffer: buffer must not be empty")
+	}
+	if len(buf)%8 != 0 {
+		return nil, errors.New("NewFromBuffer: buffer length must be a multiple of 8 bytes")
+	}
+	return unsafe.Slice((*uint64)(unsafe.Pointer(&buf[0])), len(buf)/8), nil
+}
+
+// nextPowerOfTwo returns the smallest power of two >= n.
+func nextPowerOfTwo(n uint) uint {
+	if n <= 1 {
+		return 1
+	}
+	p := uint(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
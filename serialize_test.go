@@ -0,0 +1,125 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteToReadFrom(t *testing.T) {
+	f, _ := New(1000, 4)
+	f.Add([]byte("x"))
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	g := &BloomFilter{}
+	if _, err := g.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if !g.Equal(f) {
+		t.Errorf("round-tripped filter should Equal the original")
+	}
+}
+
+func TestReadFromRejectsBadMagic(t *testing.T) {
+	g := &BloomFilter{}
+	// A full-length header (2-byte magic, version byte, m and k) of zeros,
+	// so ReadFrom gets as far as checking the magic.
+	if _, err := g.ReadFrom(bytes.NewReader(make([]byte, 19))); err == nil {
+		t.Errorf("ReadFrom should reject data with a bad magic header")
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	f, _ := New(1000, 4)
+	f.Add([]byte("x"))
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(f); err != nil {
+		t.Fatalf("gob encode: %v", err)
+	}
+
+	g := &BloomFilter{}
+	if err := gob.NewDecoder(&buf).Decode(g); err != nil {
+		t.Fatalf("gob decode: %v", err)
+	}
+	if !g.Equal(f) {
+		t.Errorf("gob round-tripped filter should Equal the original")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	f, _ := New(1000, 4)
+	f.Add([]byte("x"))
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	g := &BloomFilter{}
+	if err := json.Unmarshal(data, g); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if !g.Equal(f) {
+		t.Errorf("JSON round-tripped filter should Equal the original")
+	}
+}
+
+func TestNewFromBufferIsZeroCopy(t *testing.T) {
+	// 4 words (256 bits): already a power of two bit length, so
+	// NewFromBuffer should alias buf rather than growing into a copy.
+	buf := make([]byte, 4*8)
+	f, err := NewFromBuffer(buf, 4)
+	if err != nil {
+		t.Fatalf("NewFromBuffer: %v", err)
+	}
+	if f.Cap() != uint(len(buf))*8 {
+		t.Errorf("Cap() = %d, want %d", f.Cap(), len(buf)*8)
+	}
+
+	f.Add([]byte("x"))
+	allZero := true
+	for _, w := range buf {
+		if w != 0 {
+			allZero = false
+		}
+	}
+	if allZero {
+		t.Errorf("Add should have written through to the caller's backing buffer, but buf is still all zero")
+	}
+}
+
+func TestNewFromBufferRoundsUpNonPowerOfTwo(t *testing.T) {
+	// 3 words (192 bits): not a power of two, so NewFromBuffer must grow
+	// into a fresh, larger bitset rather than returning a mismatched mask.
+	buf := make([]byte, 3*8)
+	f, err := NewFromBuffer(buf, 4)
+	if err != nil {
+		t.Fatalf("NewFromBuffer: %v", err)
+	}
+	if f.Cap()&(f.Cap()-1) != 0 {
+		t.Errorf("Cap() = %d, want a power of two", f.Cap())
+	}
+	if f.Cap() <= uint(len(buf))*8 {
+		t.Errorf("Cap() = %d, want strictly more bits than the undersized buffer (%d)", f.Cap(), len(buf)*8)
+	}
+
+	f.Add([]byte("x"))
+	if !f.Test([]byte("x")) {
+		t.Errorf("expected x to be present after Add")
+	}
+}
+
+func TestNewFromBufferRejectsBadLength(t *testing.T) {
+	if _, err := NewFromBuffer(nil, 4); err == nil {
+		t.Errorf("NewFromBuffer should reject an empty buffer")
+	}
+	if _, err := NewFromBuffer(make([]byte, 7), 4); err == nil {
+		t.Errorf("NewFromBuffer should reject a buffer whose length isn't a multiple of 8 bytes")
+	}
+}
@@ -0,0 +1,81 @@
+package bloom
+
+import "testing"
+
+func TestCopy(t *testing.T) {
+	f, _ := New(1000, 4)
+	f.Add([]byte("x"))
+
+	g := f.Copy()
+	if !g.Equal(f) {
+		t.Errorf("Copy of a filter should Equal the original")
+	}
+
+	g.Add([]byte("y"))
+	if f.Test([]byte("y")) {
+		t.Errorf("mutating the copy should not affect the original")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, _ := New(1000, 4)
+	b, _ := New(1000, 4)
+	a.Add([]byte("x"))
+	b.Add([]byte("y"))
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if !a.Test([]byte("x")) || !a.Test([]byte("y")) {
+		t.Errorf("merged filter should contain both x and y")
+	}
+}
+
+func TestMergeRejectsMismatchedParameters(t *testing.T) {
+	a, _ := New(1000, 4)
+	b, _ := New(2000, 4)
+	if err := a.Merge(b); err == nil {
+		t.Errorf("Merge should reject filters with different m")
+	}
+
+	c, _ := New(1000, 5)
+	if err := a.Merge(c); err == nil {
+		t.Errorf("Merge should reject filters with different k")
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	a, _ := New(1000, 4)
+	b, _ := New(1000, 4)
+	a.Add([]byte("x"))
+	a.Add([]byte("y"))
+	b.Add([]byte("y"))
+
+	if err := a.Intersect(b); err != nil {
+		t.Fatalf("Intersect: %v", err)
+	}
+	if !a.Test([]byte("y")) {
+		t.Errorf("intersection should retain y, which both filters contain")
+	}
+}
+
+func TestIntersectRejectsMismatchedParameters(t *testing.T) {
+	a, _ := New(1000, 4)
+	b, _ := New(2000, 4)
+	if err := a.Intersect(b); err == nil {
+		t.Errorf("Intersect should reject filters with different m")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, _ := New(1000, 4)
+	b, _ := New(1000, 4)
+	if !a.Equal(b) {
+		t.Errorf("two empty filters with the same m, k should be Equal")
+	}
+
+	a.Add([]byte("x"))
+	if a.Equal(b) {
+		t.Errorf("filters with different contents should not be Equal")
+	}
+}